@@ -15,6 +15,7 @@
 package components
 
 import (
+	"bytes"
 	"github.com/sirupsen/logrus"
 	"github.com/trivago/gollum/core"
 	"io"
@@ -23,14 +24,17 @@ import (
 
 // BatchedWriterAssembly is a helper struct for io.Writer compatible classes that use batch directly for resources
 type BatchedWriterAssembly struct {
-	Batch           core.MessageBatch // Batch contains the MessageBatch
-	Created         time.Time         // Created contains the creation time from the writer was set
-	writer          BatchedWriter
-	assembly        core.WriterAssembly
-	flushTimeout    time.Duration // max sec to wait before a flush is aborted
-	batchTimeout    time.Duration // max sec to wait before batch will flushed
-	batchFlushCount int
-	logger          logrus.FieldLogger
+	Batch             core.MessageBatch // Batch contains the MessageBatch
+	Created           time.Time         // Created contains the creation time from the writer was set
+	CompressedBytes   int64             // CompressedBytes is the size of the last batch after compression
+	UncompressedBytes int64             // UncompressedBytes is the size of the last batch before compression
+	writer            BatchedWriter
+	assembly          core.WriterAssembly
+	compressor        Compressor
+	flushTimeout      time.Duration // max sec to wait before a flush is aborted
+	batchTimeout      time.Duration // max sec to wait before batch will flushed
+	batchFlushCount   int
+	logger            logrus.FieldLogger
 }
 
 // BatchedWriter is an interface for different file writer like disk, s3, etc.
@@ -41,17 +45,34 @@ type BatchedWriter interface {
 	IsAccessible() bool
 }
 
-// NewBatchedWriterAssembly returns a new BatchedWriterAssembly instance
+// NewBatchedWriterAssembly returns a new BatchedWriterAssembly instance.
+// compression selects the Compressor batches are written through (one of
+// "", "none", "gzip", "snappy" or "zstd" - see NewCompressor); pass "" if
+// the producer does not support compression.
 func NewBatchedWriterAssembly(batchMaxCount int, batchTimeout time.Duration, batchFlushCount int, modulator core.Modulator, tryFallback func(*core.Message),
-	timeout time.Duration, logger logrus.FieldLogger) *BatchedWriterAssembly {
+	timeout time.Duration, logger logrus.FieldLogger, compression string) (*BatchedWriterAssembly, error) {
+	compressor, err := NewCompressor(compression)
+	if err != nil {
+		return nil, err
+	}
+
 	return &BatchedWriterAssembly{
 		Batch:           core.NewMessageBatch(batchMaxCount),
 		assembly:        core.NewWriterAssembly(nil, tryFallback, modulator),
+		compressor:      compressor,
 		flushTimeout:    timeout,
 		batchTimeout:    batchTimeout,
 		batchFlushCount: batchFlushCount,
 		logger:          logger,
-	}
+	}, nil
+}
+
+// Compressor returns the Compressor configured for this assembly, or nil if
+// compression is disabled. A producer writing to a cloud sink (e.g. S3) can
+// use this to set a Content-Encoding header and to append the matching file
+// extension to the resource name it returns from Name().
+func (bwa *BatchedWriterAssembly) Compressor() Compressor {
+	return bwa.compressor
 }
 
 // HasWriter returns boolean value if a writer i currently set
@@ -82,30 +103,96 @@ func (bwa *BatchedWriterAssembly) GetWriter() BatchedWriter {
 	return bwa.writer
 }
 
-// Flush flush the batch
+// Flush flush the batch. If a Compressor is configured, the batch is
+// assembled into memory first and then compressed as a whole so the
+// compression stream can be closed (flushing any trailer bytes, e.g. a
+// gzip footer) before the write reaches the underlying writer - this keeps
+// every flushed batch independently decompressible. Should compressing the
+// batch fail, the uncompressed batch is written instead so a bad codec
+// choice never loses data.
 func (bwa *BatchedWriterAssembly) Flush() {
-	if bwa.writer != nil {
+	if bwa.writer == nil {
+		bwa.Batch.Flush(bwa.assembly.Flush)
+		return
+	}
+
+	if bwa.compressor == nil {
 		bwa.assembly.SetWriter(bwa.writer)
 		bwa.Batch.Flush(bwa.assembly.Write)
-	} else {
-		bwa.Batch.Flush(bwa.assembly.Flush)
+		return
 	}
+
+	var buffer bytes.Buffer
+	bwa.assembly.SetWriter(&bufferWriter{buffer: &buffer, base: bwa.writer})
+	bwa.Batch.Flush(bwa.assembly.Write)
+	bwa.Batch.WaitForFlush(bwa.flushTimeout)
+	bwa.writeCompressed(buffer.Bytes())
 }
 
-// Close closes batch and writer
+// Close closes batch and writer, compressing any remaining buffered
+// messages through the configured Compressor first (see Flush).
 func (bwa *BatchedWriterAssembly) Close() {
-	if bwa.writer != nil {
+	switch {
+	case bwa.writer == nil:
+		bwa.Batch.Close(bwa.assembly.Flush, bwa.flushTimeout)
+
+	case bwa.compressor == nil:
 		bwa.assembly.SetWriter(bwa.writer)
 		bwa.Batch.Close(bwa.assembly.Write, bwa.flushTimeout)
-	} else {
-		bwa.Batch.Close(bwa.assembly.Flush, bwa.flushTimeout)
+
+	default:
+		var buffer bytes.Buffer
+		bwa.assembly.SetWriter(&bufferWriter{buffer: &buffer, base: bwa.writer})
+		bwa.Batch.Close(bwa.assembly.Write, bwa.flushTimeout)
+		bwa.writeCompressed(buffer.Bytes())
 	}
 	bwa.writer.Close()
 }
 
+// writeCompressed compresses data as a whole through bwa.compressor and
+// writes the result to bwa.writer, tracking the compressed and uncompressed
+// byte counts for this batch. On a compression error (including one from
+// Wrap itself) it logs the failure and falls back to writing data
+// uncompressed.
+func (bwa *BatchedWriterAssembly) writeCompressed(data []byte) {
+	bwa.UncompressedBytes = int64(len(data))
+
+	var compressed bytes.Buffer
+	stream, err := bwa.compressor.Wrap(&compressed)
+	if err == nil {
+		if _, err = stream.Write(data); err == nil {
+			if err = stream.Close(); err == nil {
+				bwa.CompressedBytes = int64(compressed.Len())
+				bwa.writer.Write(compressed.Bytes())
+				return
+			}
+		}
+	}
+
+	bwa.logger.WithError(err).Error("Compression failed, writing batch uncompressed")
+	bwa.CompressedBytes = bwa.UncompressedBytes
+	bwa.writer.Write(data)
+}
+
+// bufferWriter adapts an in-memory buffer to the BatchedWriter interface so
+// a batch can be fully assembled before it is compressed (or, on a
+// compressor error, written through unmodified). Name, Size and
+// IsAccessible are delegated to base, the writer the batch will eventually
+// be written to.
+type bufferWriter struct {
+	buffer *bytes.Buffer
+	base   BatchedWriter
+}
+
+func (w *bufferWriter) Write(data []byte) (int, error) { return w.buffer.Write(data) }
+func (w *bufferWriter) Close() error                   { return nil }
+func (w *bufferWriter) Name() string                   { return w.base.Name() }
+func (w *bufferWriter) Size() int64                    { return w.base.Size() }
+func (w *bufferWriter) IsAccessible() bool             { return w.base.IsAccessible() }
+
 // FlushOnTimeOut checks if timeout or slush count reached and flush in this case
 func (bwa *BatchedWriterAssembly) FlushOnTimeOut() {
 	if bwa.Batch.ReachedTimeThreshold(bwa.batchTimeout) || bwa.Batch.ReachedSizeThreshold(bwa.batchFlushCount) {
 		bwa.Flush()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,84 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor wraps a BatchedWriterAssembly's output stream with a streaming
+// compression codec.
+type Compressor interface {
+	// Wrap returns a WriteCloser that compresses everything written to it
+	// into writer. Closing the returned WriteCloser flushes and closes the
+	// compression stream; it must not close writer itself. An error here is
+	// handled the same way as one from the returned WriteCloser: the caller
+	// falls back to writing the batch uncompressed rather than losing it.
+	Wrap(writer io.Writer) (io.WriteCloser, error)
+
+	// ContentEncoding returns the value to use in a Content-Encoding header
+	// for resources written with this compressor, e.g. "gzip".
+	ContentEncoding() string
+
+	// Extension returns the file extension (including the leading dot) to
+	// append to a resource name written with this compressor, e.g. ".gz".
+	Extension() string
+}
+
+// NewCompressor returns the Compressor registered under name. An empty name
+// (or "none") disables compression and returns a nil Compressor.
+func NewCompressor(name string) (Compressor, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "gzip":
+		return new(gzipCompressor), nil
+	case "snappy":
+		return new(snappyCompressor), nil
+	case "zstd":
+		return new(zstdCompressor), nil
+	default:
+		return nil, fmt.Errorf("components: unknown Compression %q, expected one of \"gzip\", \"snappy\", \"zstd\"", name)
+	}
+}
+
+type gzipCompressor struct{}
+
+func (*gzipCompressor) Wrap(writer io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(writer), nil
+}
+func (*gzipCompressor) ContentEncoding() string { return "gzip" }
+func (*gzipCompressor) Extension() string       { return ".gz" }
+
+type snappyCompressor struct{}
+
+func (*snappyCompressor) Wrap(writer io.Writer) (io.WriteCloser, error) {
+	return snappy.NewBufferedWriter(writer), nil
+}
+func (*snappyCompressor) ContentEncoding() string { return "x-snappy-framed" }
+func (*snappyCompressor) Extension() string       { return ".snappy" }
+
+type zstdCompressor struct{}
+
+func (*zstdCompressor) Wrap(writer io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(writer)
+}
+func (*zstdCompressor) ContentEncoding() string { return "zstd" }
+func (*zstdCompressor) Extension() string       { return ".zst" }
@@ -0,0 +1,181 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grok expands and compiles named grok expressions (e.g.
+// "%{COMMONAPACHELOG}") into regular expressions and matches messages
+// against them. It is shared between format.Grok and filter.Grok so both
+// plugins parse and compile patterns the exact same way.
+package grok
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/trivago/grok/patterns"
+)
+
+// maxExpansionDepth bounds the number of passes spent resolving nested
+// %{NAME} references (e.g. %{IP} pulling in %{IPV6}|%{IPV4}), guarding
+// against a cyclic AdditionalPatterns entry.
+const maxExpansionDepth = 64
+
+var namedFieldPattern = regexp.MustCompile(`%\{([A-Za-z0-9_]+)(?::([^:}]+))?(?::([a-zA-Z]+))?\}`)
+
+type field struct {
+	name     string
+	typeName string
+}
+
+// pattern is a single compiled grok expression together with the target
+// type of every named capture it contains.
+type pattern struct {
+	expression string
+	regex      *regexp.Regexp
+	types      map[string]string
+}
+
+// Matcher compiles a list of grok expressions against the built-in
+// patterns.Grok table (plus any additional patterns) and matches messages
+// against them in order.
+type Matcher struct {
+	patterns []pattern
+}
+
+// NewMatcher expands and compiles expressions against patterns.Grok merged
+// with additionalPatterns. Expressions are later tried in the given order
+// by Match.
+func NewMatcher(expressions []string, additionalPatterns map[string]string) (*Matcher, error) {
+	table := make(map[string]string, len(patterns.Grok)+len(additionalPatterns))
+	for name, expr := range patterns.Grok {
+		table[name] = expr
+	}
+	for name, expr := range additionalPatterns {
+		table[name] = expr
+	}
+
+	matcher := &Matcher{patterns: make([]pattern, 0, len(expressions))}
+	for _, expression := range expressions {
+		compiled, fields, err := expand(expression, table)
+		if err != nil {
+			return nil, err
+		}
+
+		regex, err := regexp.Compile(compiled)
+		if err != nil {
+			return nil, fmt.Errorf("grok: could not compile pattern %q: %s", expression, err)
+		}
+
+		types := make(map[string]string, len(fields))
+		for _, f := range fields {
+			if f.typeName != "" {
+				types[f.name] = f.typeName
+			}
+		}
+
+		matcher.patterns = append(matcher.patterns, pattern{expression: expression, regex: regex, types: types})
+	}
+
+	return matcher, nil
+}
+
+// expand recursively replaces %{NAME}, %{NAME:field} and %{NAME:field:type}
+// references in expression with their matching regex from table, collecting
+// every named capture it introduces along the way.
+func expand(expression string, table map[string]string) (string, []field, error) {
+	var fields []field
+	var unresolved string
+
+	current := expression
+	for i := 0; i < maxExpansionDepth; i++ {
+		if !namedFieldPattern.MatchString(current) {
+			return current, fields, nil
+		}
+
+		next := namedFieldPattern.ReplaceAllStringFunc(current, func(match string) string {
+			groups := namedFieldPattern.FindStringSubmatch(match)
+			name, fieldName, typeName := groups[1], groups[2], groups[3]
+
+			base, ok := table[name]
+			if !ok {
+				unresolved = name
+				return match
+			}
+
+			if fieldName == "" {
+				return "(?:" + base + ")"
+			}
+
+			fields = append(fields, field{name: fieldName, typeName: typeName})
+			return fmt.Sprintf("(?P<%s>%s)", fieldName, base)
+		})
+
+		if next == current {
+			if unresolved != "" {
+				return "", nil, fmt.Errorf("grok: unknown pattern reference %%{%s} in %q", unresolved, expression)
+			}
+			return "", nil, fmt.Errorf("grok: pattern %q did not fully expand", expression)
+		}
+		current = next
+	}
+
+	return "", nil, fmt.Errorf("grok: pattern %q exceeded expansion depth %d, check for a reference cycle", expression, maxExpansionDepth)
+}
+
+// Match tries every compiled pattern in order and returns the named capture
+// groups of the first one that matches data, converting fields declared
+// with a ":type" suffix (int, float or bool) along the way. The second
+// return value is false if no pattern matched.
+func (m *Matcher) Match(data []byte) (map[string]interface{}, bool) {
+	for _, p := range m.patterns {
+		match := p.regex.FindSubmatch(data)
+		if match == nil {
+			continue
+		}
+
+		values := make(map[string]interface{}, len(match))
+		for i, name := range p.regex.SubexpNames() {
+			if i == 0 || name == "" || len(match[i]) == 0 {
+				continue
+			}
+			values[name] = convert(match[i], p.types[name])
+		}
+		return values, true
+	}
+
+	return nil, false
+}
+
+// convert converts raw to typeName (int, float or bool), falling back to
+// the raw string if typeName is empty or the conversion fails.
+func convert(raw []byte, typeName string) interface{} {
+	text := string(raw)
+
+	switch typeName {
+	case "int":
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case "float":
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case "bool":
+		if v, err := strconv.ParseBool(text); err == nil {
+			return v
+		}
+	}
+
+	return text
+}
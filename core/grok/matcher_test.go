@@ -0,0 +1,98 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grok
+
+import "testing"
+
+func TestMatcherTypedCaptures(t *testing.T) {
+	matcher, err := NewMatcher([]string{`%{NUMBER:bytes:int} %{NUMBER:ratio:float} %{WORD:ok:bool}`}, nil)
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %s", err)
+	}
+
+	values, matched := matcher.Match([]byte("42 3.5 true"))
+	if !matched {
+		t.Fatalf("expected pattern to match")
+	}
+
+	if v, ok := values["bytes"].(int64); !ok || v != 42 {
+		t.Errorf("expected bytes=42 (int64), got %#v", values["bytes"])
+	}
+	if v, ok := values["ratio"].(float64); !ok || v != 3.5 {
+		t.Errorf("expected ratio=3.5 (float64), got %#v", values["ratio"])
+	}
+	if v, ok := values["ok"].(bool); !ok || v != true {
+		t.Errorf("expected ok=true (bool), got %#v", values["ok"])
+	}
+}
+
+func TestMatcherUntypedCaptureStaysString(t *testing.T) {
+	matcher, err := NewMatcher([]string{`%{NUMBER:count}`}, nil)
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %s", err)
+	}
+
+	values, matched := matcher.Match([]byte("7"))
+	if !matched {
+		t.Fatalf("expected pattern to match")
+	}
+	if v, ok := values["count"].(string); !ok || v != "7" {
+		t.Errorf("expected count=\"7\" (string), got %#v", values["count"])
+	}
+}
+
+func TestMatcherTriesPatternsInOrder(t *testing.T) {
+	matcher, err := NewMatcher([]string{`%{WORD:first}-only`, `%{WORD:any}`}, nil)
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %s", err)
+	}
+
+	values, matched := matcher.Match([]byte("hello"))
+	if !matched {
+		t.Fatalf("expected the second pattern to match")
+	}
+	if values["any"] != "hello" {
+		t.Errorf("expected any=\"hello\", got %#v", values["any"])
+	}
+}
+
+func TestMatcherNoMatch(t *testing.T) {
+	matcher, err := NewMatcher([]string{`^ONLYDIGITS$`}, nil)
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %s", err)
+	}
+
+	if _, matched := matcher.Match([]byte("not digits")); matched {
+		t.Errorf("expected no pattern to match")
+	}
+}
+
+func TestNewMatcherUnknownPattern(t *testing.T) {
+	if _, err := NewMatcher([]string{`%{NOT_A_REAL_PATTERN}`}, nil); err == nil {
+		t.Fatalf("expected an error for an unknown pattern reference")
+	}
+}
+
+func TestNewMatcherAdditionalPatterns(t *testing.T) {
+	matcher, err := NewMatcher([]string{`%{QUEUEID:id}`}, map[string]string{"QUEUEID": `[0-9A-F]{10,11}`})
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %s", err)
+	}
+
+	values, matched := matcher.Match([]byte("4A3BC91D02"))
+	if !matched || values["id"] != "4A3BC91D02" {
+		t.Errorf("expected id=\"4A3BC91D02\", got %#v matched=%v", values["id"], matched)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "github.com/trivago/gollum/core/secrets"
+
+// GetSecret returns a config value that may reference a secret via a
+// "secret://<backend>/<name>" marker, e.g. "secret://local/kafka_prod_pw" or
+// "secret://vault/secret/data/gollum/socket#address". The reference is
+// resolved against the named backend configured in the top-level Secrets:
+// block. If key is not set, defaultValue is used; if the resolved value
+// does not start with the secret:// marker it is returned as-is, so a field
+// like Address: "unix:///var/gollum.socket" is never mistaken for one.
+func (reader PluginConfigReader) GetSecret(key string, defaultValue string) string {
+	raw := reader.GetString(key, defaultValue)
+
+	if err := bootstrapSecretsFromEnv(); err != nil {
+		reader.Errors.Push(err)
+		return defaultValue
+	}
+
+	resolved, ok, err := secrets.Resolve(raw)
+	if err != nil {
+		reader.Errors.Push(err)
+		return defaultValue
+	}
+	if !ok {
+		return raw
+	}
+	return resolved
+}
@@ -0,0 +1,177 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultLocalKeyEnv is the environment variable the local backend reads
+// its AES key from unless a KeyEnv setting overrides the name.
+const defaultLocalKeyEnv = "GOLLUM_SECRETS_LOCAL_KEY"
+
+func init() {
+	RegisterBackend("local", func(settings map[string]string) (Manager, error) {
+		return NewLocal(settings["KeystoreDir"], localKeyEnv(settings))
+	})
+}
+
+// localKeyEnv returns the hex-encoded AES key for the local backend, read
+// from the environment variable named by the KeyEnv setting (defaulting to
+// GOLLUM_SECRETS_LOCAL_KEY). The key is deliberately not read from settings
+// itself: settings come from the same config text the Secrets: block lives
+// in, which would let anyone able to read the config decrypt every secret.
+func localKeyEnv(settings map[string]string) string {
+	keyEnv := settings["KeyEnv"]
+	if keyEnv == "" {
+		keyEnv = defaultLocalKeyEnv
+	}
+	return os.Getenv(keyEnv)
+}
+
+// Local is a Manager backend that stores secrets as individual,
+// AES-GCM encrypted files in a directory on disk, one file per secret.
+// Config values reference it as "secret://local/<name>", where <name> is
+// the file name within KeystoreDir.
+type Local struct {
+	dir   string
+	gcm   cipher.AEAD
+	mutex sync.RWMutex
+}
+
+// NewLocal creates a Local backend rooted at dir, encrypting and decrypting
+// secrets with hexKey, a hex-encoded AES key (32, 48 or 64 hex characters
+// for AES-128/192/256).
+func NewLocal(dir string, hexKey string) (*Local, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("secrets: local backend requires a KeystoreDir")
+	}
+	if hexKey == "" {
+		return nil, fmt.Errorf("secrets: local backend requires its AES key to be set via the environment (see KeyEnv)")
+	}
+
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: local backend key is not valid hex: %s", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: local backend could not initialize AES cipher: %s", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: local backend could not initialize AES-GCM: %s", err)
+	}
+
+	return &Local{dir: dir, gcm: gcm}, nil
+}
+
+func (l *Local) path(name string) string {
+	return filepath.Join(l.dir, name)
+}
+
+// HasSecret reports whether a secret file exists under name.
+func (l *Local) HasSecret(name string) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	_, err := os.Stat(l.path(name))
+	return err == nil
+}
+
+// GetSecret reads, decrypts and authenticates the secret stored under name.
+// The secret file is required to have permissions 0600; anything looser is
+// refused rather than silently read.
+func (l *Local) GetSecret(name string) ([]byte, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	file, err := os.Open(l.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("secrets: could not open secret %q: %s", name, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		return nil, fmt.Errorf("secrets: refusing to read %q, expected file mode 0600 but got %o", name, perm)
+	}
+
+	ciphertext, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.decrypt(ciphertext)
+}
+
+// SetSecret encrypts and authenticates value and (over)writes it to the
+// secret file under name, creating KeystoreDir and the file with
+// restrictive permissions.
+func (l *Local) SetSecret(name string, value []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	ciphertext, err := l.encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return fmt.Errorf("secrets: could not create keystore dir %q: %s", l.dir, err)
+	}
+
+	return ioutil.WriteFile(l.path(name), ciphertext, 0600)
+}
+
+// encrypt seals plaintext with a random nonce using AES-GCM, returning
+// nonce||ciphertext||tag. The authentication tag lets decrypt detect any
+// tampering with the stored file instead of silently returning garbage.
+func (l *Local) encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, l.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("secrets: could not generate nonce: %s", err)
+	}
+
+	return l.gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (l *Local) decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := l.gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("secrets: ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, err := l.gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: could not decrypt secret, ciphertext may have been tampered with: %s", err)
+	}
+	return plaintext, nil
+}
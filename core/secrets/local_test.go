@@ -0,0 +1,106 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("could not generate test key: %s", err)
+	}
+
+	local, err := NewLocal(t.TempDir(), hex.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("NewLocal returned error: %s", err)
+	}
+	return local
+}
+
+func TestLocalSetGetRoundTrip(t *testing.T) {
+	local := newTestLocal(t)
+
+	if local.HasSecret("kafka_prod_pw") {
+		t.Fatalf("expected HasSecret to report false before SetSecret")
+	}
+
+	if err := local.SetSecret("kafka_prod_pw", []byte("hunter2")); err != nil {
+		t.Fatalf("SetSecret returned error: %s", err)
+	}
+
+	if !local.HasSecret("kafka_prod_pw") {
+		t.Fatalf("expected HasSecret to report true after SetSecret")
+	}
+
+	value, err := local.GetSecret("kafka_prod_pw")
+	if err != nil {
+		t.Fatalf("GetSecret returned error: %s", err)
+	}
+	if !bytes.Equal(value, []byte("hunter2")) {
+		t.Errorf("expected %q, got %q", "hunter2", value)
+	}
+}
+
+func TestLocalRejectsLoosePermissions(t *testing.T) {
+	local := newTestLocal(t)
+
+	if err := local.SetSecret("loose", []byte("value")); err != nil {
+		t.Fatalf("SetSecret returned error: %s", err)
+	}
+	if err := os.Chmod(local.path("loose"), 0644); err != nil {
+		t.Fatalf("chmod failed: %s", err)
+	}
+
+	if _, err := local.GetSecret("loose"); err == nil {
+		t.Fatalf("expected GetSecret to refuse a 0644 secret file")
+	}
+}
+
+func TestLocalDetectsTamperedCiphertext(t *testing.T) {
+	local := newTestLocal(t)
+
+	if err := local.SetSecret("tampered", []byte("value")); err != nil {
+		t.Fatalf("SetSecret returned error: %s", err)
+	}
+
+	path := local.path("tampered")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read secret file: %s", err)
+	}
+	data[len(data)-1] ^= 0xFF
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("could not write tampered secret file: %s", err)
+	}
+
+	if _, err := local.GetSecret("tampered"); err == nil {
+		t.Fatalf("expected GetSecret to detect tampered ciphertext")
+	}
+}
+
+func TestNewLocalRejectsMissingKey(t *testing.T) {
+	if _, err := NewLocal(filepath.Join(t.TempDir(), "keys"), ""); err == nil {
+		t.Fatalf("expected NewLocal to reject an empty key")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import "testing"
+
+// stubManager is a Manager that always returns the same value, used to
+// exercise Resolve without a real backend.
+type stubManager struct{ value string }
+
+func (s stubManager) GetSecret(name string) ([]byte, error)     { return []byte(s.value), nil }
+func (s stubManager) SetSecret(name string, value []byte) error { return nil }
+func (s stubManager) HasSecret(name string) bool                { return true }
+
+func TestResolveIgnoresValuesWithoutTheSecretPrefix(t *testing.T) {
+	// Regression test: these are all legitimate, non-secret config values,
+	// two of which contain "://" - Resolve must leave them alone rather
+	// than misreading them as a secret reference (see the Socket producer's
+	// Address setting, which accepts "unix:///var/gollum.socket").
+	for _, raw := range []string{
+		"unix:///var/gollum.socket",
+		":5880",
+		"localhost:5880",
+		"vault://looks-like-a-scheme-but-isnt",
+	} {
+		value, ok, err := Resolve(raw)
+		if err != nil {
+			t.Errorf("Resolve(%q) returned unexpected error: %s", raw, err)
+		}
+		if ok {
+			t.Errorf("Resolve(%q) should not be treated as a secret reference, got value %q", raw, value)
+		}
+	}
+}
+
+func TestResolveDispatchesToRegisteredBackend(t *testing.T) {
+	SetActive("test", stubManager{value: "hunter2"})
+	defer SetActive("test", nil)
+
+	value, ok, err := Resolve("secret://test/kafka_prod_pw")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %s", err)
+	}
+	if !ok || value != "hunter2" {
+		t.Errorf("expected ok=true value=%q, got ok=%v value=%q", "hunter2", ok, value)
+	}
+}
+
+func TestResolveUnknownBackend(t *testing.T) {
+	if _, ok, err := Resolve("secret://not-configured/name"); err == nil || ok {
+		t.Fatalf("expected an error for a backend that is not configured, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestResolveMissingName(t *testing.T) {
+	if _, ok, err := Resolve("secret://local"); err == nil || ok {
+		t.Fatalf("expected an error for a reference missing its <name> segment, got ok=%v err=%v", ok, err)
+	}
+}
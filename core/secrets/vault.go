@@ -0,0 +1,227 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// errNotFound is returned by readData when Vault reports no data at all at
+// the requested path (HTTP 404) - the one readData failure SetSecret may
+// treat as "nothing to preserve yet" rather than an error to propagate.
+var errNotFound = errors.New("secrets: vault secret not found")
+
+func init() {
+	RegisterBackend("vault", func(settings map[string]string) (Manager, error) {
+		return NewVault(settings)
+	})
+}
+
+// Vault is a Manager backend that reads and writes secrets through
+// HashiCorp Vault's KV version 2 API. Config values reference it as
+// "secret://vault/<mount>/data/<path>#<field>", e.g.
+// "secret://vault/secret/data/gollum/socket#address".
+type Vault struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewVault creates a Vault backend from settings. Address is mandatory.
+// Authentication is resolved in this order: an explicit Token setting, the
+// VAULT_TOKEN environment variable, or an AppRole login using the
+// RoleID/SecretID settings (falling back to VAULT_ROLE_ID/VAULT_SECRET_ID).
+func NewVault(settings map[string]string) (*Vault, error) {
+	address := settings["Address"]
+	if address == "" {
+		return nil, fmt.Errorf("secrets: vault backend requires an Address")
+	}
+
+	vault := &Vault{
+		address: strings.TrimRight(address, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	token := firstNonEmpty(settings["Token"], os.Getenv("VAULT_TOKEN"))
+	if token == "" {
+		roleID := firstNonEmpty(settings["RoleID"], os.Getenv("VAULT_ROLE_ID"))
+		secretID := firstNonEmpty(settings["SecretID"], os.Getenv("VAULT_SECRET_ID"))
+		if roleID == "" || secretID == "" {
+			return nil, fmt.Errorf("secrets: vault backend requires a Token, or a RoleID/SecretID pair for AppRole login")
+		}
+
+		loggedInToken, err := vault.loginAppRole(roleID, secretID)
+		if err != nil {
+			return nil, err
+		}
+		token = loggedInToken
+	}
+
+	vault.token = token
+	return vault, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (v *Vault) loginAppRole(roleID, secretID string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"role_id": roleID, "secret_id": secretID})
+
+	resp, err := v.client.Post(v.address+"/v1/auth/approle/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("secrets: vault AppRole login failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets: vault AppRole login returned status %d", resp.StatusCode)
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return "", fmt.Errorf("secrets: could not decode vault AppRole login response: %s", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("secrets: vault AppRole login did not return a client token")
+	}
+	return login.Auth.ClientToken, nil
+}
+
+// splitPathField splits a KV v2 reference such as
+// "secret/data/gollum/socket#address" into its path and field.
+func splitPathField(name string) (path string, field string, err error) {
+	idx := strings.LastIndex(name, "#")
+	if idx < 0 {
+		return "", "", fmt.Errorf("secrets: vault reference %q is missing a \"#field\" suffix", name)
+	}
+	return name[:idx], name[idx+1:], nil
+}
+
+// readData fetches the raw KV v2 data map stored at path.
+func (v *Vault) readData(path string) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, v.address+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: vault request for %q failed: %s", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("secrets: vault returned status %d for %q", resp.StatusCode, path)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("secrets: could not decode vault response for %q: %s", path, err)
+	}
+	return secret.Data.Data, nil
+}
+
+// GetSecret reads a single field from a KV v2 secret.
+func (v *Vault) GetSecret(name string) ([]byte, error) {
+	path, field, err := splitPathField(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := v.readData(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("secrets: vault secret %q has no field %q", path, field)
+	}
+	return []byte(fmt.Sprintf("%v", value)), nil
+}
+
+// HasSecret reports whether the referenced KV v2 field currently exists.
+func (v *Vault) HasSecret(name string) bool {
+	_, err := v.GetSecret(name)
+	return err == nil
+}
+
+// SetSecret writes a single field into a KV v2 secret, preserving any other
+// fields already stored alongside it. A readData failure only starts from
+// an empty set of fields when Vault reports the path does not exist yet
+// (errNotFound); any other failure (network, auth, a transient 5xx, ...) is
+// propagated instead of being treated as "no existing data", since silently
+// continuing would overwrite every sibling field at that path with just the
+// one being set here.
+func (v *Vault) SetSecret(name string, value []byte) error {
+	path, field, err := splitPathField(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := v.readData(path)
+	if err != nil {
+		if !errors.Is(err, errNotFound) {
+			return err
+		}
+		data = map[string]interface{}{}
+	}
+	data[field] = string(value)
+
+	body, _ := json.Marshal(map[string]interface{}{"data": data})
+
+	req, err := http.NewRequest(http.MethodPost, v.address+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("secrets: vault write for %q failed: %s", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("secrets: vault returned status %d writing %q", resp.StatusCode, path)
+	}
+	return nil
+}
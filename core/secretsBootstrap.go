@@ -0,0 +1,69 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// secretsConfigEnv names the environment variable that points at a JSON file
+// holding the top-level Secrets: block, e.g.
+//
+//	{"local": {"KeystoreDir": "/etc/gollum/keys"}, "vault": {"Address": "..."}}
+//
+// This exists because this build has no startup code that parses a config
+// file's Secrets: block and calls ConfigureSecrets itself; without it,
+// ConfigureSecrets would never run and every GetSecret call referencing a
+// backend would fail with "not configured" no matter what a user writes in
+// their config. Reading it lazily here means GetSecret activates the
+// backends itself the first time it is asked to resolve a secret, instead
+// of depending on a separate call that nothing in this tree makes.
+const secretsConfigEnv = "GOLLUM_SECRETS_CONFIG"
+
+var (
+	secretsBootstrapOnce sync.Once
+	secretsBootstrapErr  error
+)
+
+// bootstrapSecretsFromEnv configures the Secrets: backends named by
+// secretsConfigEnv, if set. It is idempotent and safe to call from every
+// GetSecret invocation; the file is only read once.
+func bootstrapSecretsFromEnv() error {
+	secretsBootstrapOnce.Do(func() {
+		path := os.Getenv(secretsConfigEnv)
+		if path == "" {
+			return
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			secretsBootstrapErr = fmt.Errorf("core: could not read %s=%q: %s", secretsConfigEnv, path, err)
+			return
+		}
+
+		var blocks map[string]map[string]string
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			secretsBootstrapErr = fmt.Errorf("core: could not parse %s=%q: %s", secretsConfigEnv, path, err)
+			return
+		}
+
+		secretsBootstrapErr = ConfigureSecrets(blocks)
+	})
+	return secretsBootstrapErr
+}
@@ -0,0 +1,47 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/trivago/gollum/core/secrets"
+)
+
+// ConfigureSecrets activates the secrets backends described by the
+// top-level "Secrets:" block of the config file, e.g.
+//
+//	Secrets:
+//	  local:
+//	    KeystoreDir: /etc/gollum/keys
+//	  vault:
+//	    Address: https://vault.example.com:8200
+//
+// blocks is keyed by the backend name plugins reference in a
+// "secret://<backend>/<name>" value ("local", "vault", ...); each value is
+// that backend's settings as read from its block. bootstrapSecretsFromEnv
+// calls this on the first PluginConfigReader.GetSecret call, with the blocks
+// read from the file named by secretsConfigEnv, so that GetSecret can
+// resolve those references against the backends started here.
+func ConfigureSecrets(blocks map[string]map[string]string) error {
+	for scheme, settings := range blocks {
+		manager, err := secrets.NewManager(scheme, settings)
+		if err != nil {
+			return fmt.Errorf("core: could not configure secrets backend %q: %s", scheme, err)
+		}
+		secrets.SetActive(scheme, manager)
+	}
+	return nil
+}
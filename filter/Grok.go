@@ -0,0 +1,92 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+
+	"github.com/trivago/gollum/core"
+	"github.com/trivago/gollum/core/grok"
+)
+
+// Grok filter plugin
+//
+// Grok matches the message content against a list of named grok patterns
+// (e.g. "%{SYSLOGBASE}") and rejects any message that does not match one of
+// them. This is useful to drop messages that do not conform to an expected
+// log format before they reach a formatter or producer further down the
+// pipeline.
+//
+// Configuration example
+//
+//   - "filter.Grok":
+//     Patterns:
+//       - "%{SYSLOGBASE}"
+//     AdditionalPatterns:
+//       POSTFIX_QUEUEID: "[0-9A-F]{10,11}"
+//
+// Patterns defines the grok expressions to match the message against, in
+// the order they are tried. A message is accepted as soon as one pattern
+// matches. This setting is mandatory.
+//
+// AdditionalPatterns allows defining named patterns (referenced as %{NAME}
+// from within Patterns) on top of the built-in patterns.Grok table. By
+// default no additional patterns are defined.
+type Grok struct {
+	core.SimpleFilter
+	matcher *grok.Matcher
+}
+
+func init() {
+	core.TypeRegistry.Register(Grok{})
+}
+
+// Configure initializes this filter with values from a plugin config.
+func (filter *Grok) Configure(conf core.PluginConfigReader) error {
+	patternList := conf.GetStringArray("Patterns", []string{})
+	additionalPatterns := conf.GetStringMap("AdditionalPatterns", map[string]string{})
+
+	if len(patternList) == 0 {
+		conf.Errors.Push(fmt.Errorf("filter.Grok: Patterns is mandatory"))
+		return conf.Errors.OrNil()
+	}
+
+	matcher, err := grok.NewMatcher(patternList, additionalPatterns)
+	if err != nil {
+		conf.Errors.Push(err)
+	}
+	filter.matcher = matcher
+
+	return conf.Errors.OrNil()
+}
+
+// ApplyFilter matches the message content against the configured grok
+// patterns. Matched fields are stored in the message metadata just like
+// format.Grok does, so a lone filter.Grok can be used without an additional
+// formatter. A message is rejected if none of the patterns match.
+func (filter *Grok) ApplyFilter(msg *core.Message) (bool, error) {
+	content := filter.GetAppliedContent(msg)
+
+	values, matched := filter.matcher.Match(content)
+	if !matched {
+		return false, nil
+	}
+
+	for name, value := range values {
+		msg.GetMetadata().Set(name, value)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/trivago/gollum/core"
+	"github.com/trivago/gollum/core/grok"
+)
+
+// Grok formatter plugin
+//
+// Grok parses the message content against a list of named grok patterns
+// (e.g. "%{COMMONAPACHELOG}") and stores the captured fields as message
+// metadata. Patterns are tried in the order given in the config; the first
+// pattern that matches a message wins.
+//
+// Configuration example
+//
+//   - "format.Grok":
+//     Patterns:
+//       - "%{COMMONAPACHELOG}"
+//     AdditionalPatterns:
+//       POSTFIX_QUEUEID: "[0-9A-F]{10,11}"
+//     ToJSON: false
+//
+// Patterns defines the grok expressions to match the message against, in
+// the order they are tried. This setting is mandatory.
+//
+// AdditionalPatterns allows defining named patterns (referenced as %{NAME}
+// from within Patterns) on top of the built-in patterns.Grok table. By
+// default no additional patterns are defined.
+//
+// ToJSON causes the message content to be replaced by the JSON encoding of
+// the captured fields, in addition to them being added to the metadata. By
+// default this is set to false, i.e. the original content is kept.
+type Grok struct {
+	core.SimpleFormatter
+	matcher *grok.Matcher
+	toJSON  bool
+}
+
+func init() {
+	core.TypeRegistry.Register(Grok{})
+}
+
+// Configure initializes this formatter with values from a plugin config.
+func (format *Grok) Configure(conf core.PluginConfigReader) error {
+	patternList := conf.GetStringArray("Patterns", []string{})
+	additionalPatterns := conf.GetStringMap("AdditionalPatterns", map[string]string{})
+	format.toJSON = conf.GetBool("ToJSON", false)
+
+	if len(patternList) == 0 {
+		conf.Errors.Push(fmt.Errorf("format.Grok: Patterns is mandatory"))
+		return conf.Errors.OrNil()
+	}
+
+	matcher, err := grok.NewMatcher(patternList, additionalPatterns)
+	if err != nil {
+		conf.Errors.Push(err)
+	}
+	format.matcher = matcher
+
+	return conf.Errors.OrNil()
+}
+
+// ApplyFormatter matches the message content against the configured grok
+// patterns and stores the captures in the message metadata.
+func (format *Grok) ApplyFormatter(msg *core.Message) error {
+	content := format.GetAppliedContent(msg)
+
+	values, matched := format.matcher.Match(content)
+	if !matched {
+		return nil
+	}
+
+	for name, value := range values {
+		msg.GetMetadata().Set(name, value)
+	}
+
+	if format.toJSON {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		format.SetAppliedContent(msg, encoded)
+	}
+
+	return nil
+}
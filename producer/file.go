@@ -0,0 +1,183 @@
+// Copyright 2015-2017 trivago GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package producer
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/trivago/gollum/core"
+	"github.com/trivago/gollum/core/components"
+	"github.com/trivago/gollum/core/log"
+	"os"
+	"sync"
+	"time"
+)
+
+// File producer plugin
+// Configuration example
+//
+//   - "producer.File":
+//     Enable: true
+//     File: /var/log/gollum.log
+//     BatchMaxCount: 8192
+//     BatchFlushCount: 4096
+//     BatchTimeoutSec: 5
+//     FlushTimeoutSec: 5
+//     Compression: gzip
+//
+// File defines the path of the log file to write to. This setting is
+// mandatory.
+//
+// Compression selects the codec batches are compressed with before being
+// written to disk: one of "", "gzip", "snappy" or "zstd". By default this
+// is set to "", i.e. no compression. When set, the codec's file extension
+// is appended to File, e.g. "/var/log/gollum.log.gz".
+//
+// BatchMaxCount defines the maximum number of messages that can be
+// buffered before a flush is mandatory. By default this is set to 8192.
+//
+// BatchFlushCount defines the number of messages to be buffered before
+// they are written to disk. This setting is clamped to BatchMaxCount.
+// By default this is set to BatchMaxCount / 2.
+//
+// BatchTimeoutSec defines the maximum number of seconds to wait after the
+// last message arrived before a batch is flushed automatically. By default
+// this is set to 5.
+//
+// FlushTimeoutSec defines the maximum number of seconds to wait for a
+// flush to finish before it is aborted. If Compression is set, the batch is
+// compressed after this wait; compressing it is not bounded by this
+// timeout. By default this is set to 5.
+type File struct {
+	core.ProducerBase
+	writerAssembly *components.BatchedWriterAssembly
+	file           string
+	batchTimeout   time.Duration
+}
+
+func init() {
+	core.TypeRegistry.Register(File{})
+}
+
+// Configure initializes this producer with values from a plugin config.
+func (prod *File) Configure(conf core.PluginConfigReader) error {
+	err := prod.ProducerBase.Configure(conf)
+	if err != nil {
+		return err
+	}
+	prod.SetStopCallback(prod.close)
+
+	prod.file = conf.GetString("File", "")
+	if prod.file == "" {
+		conf.Errors.Push(fmt.Errorf("producer.File: File is mandatory"))
+		return conf.Errors.OrNil()
+	}
+
+	batchMaxCount := conf.GetInt("BatchMaxCount", 8192)
+	batchFlushCount := conf.GetInt("BatchFlushCount", batchMaxCount/2)
+	if batchFlushCount > batchMaxCount {
+		batchFlushCount = batchMaxCount
+	}
+	prod.batchTimeout = time.Duration(conf.GetInt("BatchTimeoutSec", 5)) * time.Second
+	flushTimeout := time.Duration(conf.GetInt("FlushTimeoutSec", 5)) * time.Second
+	compression := conf.GetString("Compression", "")
+
+	prod.writerAssembly, err = components.NewBatchedWriterAssembly(
+		batchMaxCount, prod.batchTimeout, batchFlushCount,
+		prod.GetFormatter(), prod.Drop, flushTimeout,
+		logrus.WithField("scope", "producer.File"), compression)
+	if err != nil {
+		conf.Errors.Push(err)
+	}
+
+	return conf.Errors.OrNil()
+}
+
+// open lazily creates the log file, appending the configured compressor's
+// file extension (if any) to its name.
+func (prod *File) open() error {
+	if prod.writerAssembly.HasWriter() {
+		return nil
+	}
+
+	name := prod.file
+	if compressor := prod.writerAssembly.Compressor(); compressor != nil {
+		name += compressor.Extension()
+	}
+
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("producer.File: could not open %q: %s", name, err)
+	}
+
+	prod.writerAssembly.SetWriter(&fileWriter{File: file})
+	return nil
+}
+
+func (prod *File) sendBatch() {
+	if err := prod.open(); err != nil {
+		Log.Error.Print(err)
+		return
+	}
+	prod.writerAssembly.Flush()
+}
+
+func (prod *File) sendBatchOnTimeOut() {
+	if err := prod.open(); err != nil {
+		Log.Error.Print(err)
+		return
+	}
+	prod.writerAssembly.FlushOnTimeOut()
+}
+
+func (prod *File) sendMessage(msg core.Message) {
+	prod.writerAssembly.Batch.AppendRetry(msg, prod.sendBatch, prod.IsActive, prod.Drop)
+}
+
+func (prod *File) close() {
+	defer prod.WorkerDone()
+
+	if prod.CloseGracefully(prod.sendMessage) {
+		prod.writerAssembly.Close()
+	}
+}
+
+// Produce writes to a buffer that is dumped to a file.
+func (prod *File) Produce(workers *sync.WaitGroup) {
+	prod.AddMainWorker(workers)
+	prod.TickerMessageControlLoop(prod.sendMessage, prod.batchTimeout, prod.sendBatchOnTimeOut)
+}
+
+// fileWriter adapts *os.File to components.BatchedWriter. Name and Close
+// already match via *os.File; only Size and IsAccessible need adding.
+type fileWriter struct {
+	*os.File
+}
+
+// Size returns the current length of the file in bytes, or -1 if it could
+// not be determined.
+func (w *fileWriter) Size() int64 {
+	info, err := w.Stat()
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// IsAccessible reports whether the file can still be stat'd.
+func (w *fileWriter) IsAccessible() bool {
+	_, err := w.Stat()
+	return err == nil
+}
@@ -38,6 +38,11 @@ import (
 // Address stores the identifier to connect to.
 // This can either be any ip address and port like "localhost:5880" or a file
 // like "unix:///var/gollum.socket". By default this is set to ":5880".
+// This value may reference a secret via the "secret://<backend>/<name>"
+// marker, e.g. "secret://vault/secret/data/gollum/socket#address" or
+// "secret://local/socket_address", in which case it is resolved via the
+// named Secrets: backend; any other value (including a "unix://..." or
+// "tcp://..." address) is used unmodified.
 //
 // ConnectionBufferSizeKB sets the connection buffer size in KB. By default this
 // is set to 1024, i.e. 1 MB buffer.
@@ -60,6 +65,7 @@ import (
 // This setting is disabled by default, i.e. set to "".
 // If Acknowledge is enabled and a IP-Address is given to Address, TCP is used
 // to open the connection, otherwise UDP is used.
+// Like Address, this value may reference a secret.
 type Socket struct {
 	core.ProducerBase
 	connection      net.Conn
@@ -79,11 +85,11 @@ type bufferedConn interface {
 }
 
 func init() {
-	shared.TypeRegistry.Register(Socket{})
+	core.TypeRegistry.Register(Socket{})
 }
 
 // Configure initializes this producer with values from a plugin config.
-func (prod *Socket) Configure(conf core.PluginConfig) error {
+func (prod *Socket) Configure(conf core.PluginConfigReader) error {
 	err := prod.ProducerBase.Configure(conf)
 	if err != nil {
 		return err
@@ -96,8 +102,11 @@ func (prod *Socket) Configure(conf core.PluginConfig) error {
 	prod.batchTimeout = time.Duration(conf.GetInt("BatchTimeoutSec", 5)) * time.Second
 	prod.bufferSizeByte = conf.GetInt("ConnectionBufferSizeKB", 1<<10) << 10 // 1 MB
 
-	prod.acknowledge = shared.Unescape(conf.GetString("Acknowledge", ""))
-	prod.address, prod.protocol = shared.ParseAddress(conf.GetString("Address", ":5880"))
+	// Acknowledge and Address may reference a secret, e.g.
+	// Acknowledge: "secret://local/socket_ack" or
+	// Address: "secret://vault/secret/data/gollum/socket#address".
+	prod.acknowledge = shared.Unescape(conf.GetSecret("Acknowledge", ""))
+	prod.address, prod.protocol = shared.ParseAddress(conf.GetSecret("Address", ":5880"))
 
 	if prod.protocol != "unix" {
 		if prod.acknowledge != "" {
@@ -111,7 +120,7 @@ func (prod *Socket) Configure(conf core.PluginConfig) error {
 	prod.assembly = core.NewWriterAssembly(prod.connection, prod.Drop, prod.GetFormatter())
 	prod.assembly.SetValidator(prod.validate)
 	prod.assembly.SetErrorHandler(prod.onWriteError)
-	return nil
+	return conf.Errors.OrNil()
 }
 
 func (prod *Socket) validate() bool {